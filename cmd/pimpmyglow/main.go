@@ -0,0 +1,349 @@
+// Command pimpmyglow compiles PimpMyGlow club programs into plain
+// Aerotech text, resolving named colors, Audacity labels, and CLUBS
+// specialization along the way.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonongjs/PimpMyGlow/glow"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "compile":
+		err = runCompile(os.Args[2:], "raw")
+	case "annotate":
+		err = runCompile(os.Args[2:], "annotated")
+	case "check":
+		err = runCheck(os.Args[2:])
+	case "simulate":
+		err = runSimulate(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "pimpmyglow: unknown subcommand %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pimpmyglow: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "%s", `usage: pimpmyglow <compile|annotate|check|simulate> --program FILE [flags]
+
+  compile    resolve a program and emit it as Aerotech text (or --format json)
+  annotate   like compile, but with "; time N" comments after each command that advances the clock
+  check      validate a program and report all problems, without emitting output
+  simulate   render the resolved program to a frame-by-frame timeline
+
+common flags:
+  --program FILE     program source to compile (required)
+  --labels FILE       Audacity label file (.aup, .aup3, or exported .txt)
+  --club N            club number to specialize for (repeatable)
+  --all-clubs         emit one output per club referenced by the program
+  --out FILE          output path; with multiple clubs, must contain "%d"
+  --format FORMAT     raw, annotated, or json (compile/annotate only)
+
+simulate flags:
+  --rate HZ           sampling rate in Hz (default 100, matching the centisecond time unit)
+  --format FORMAT     json, csv, or png (default json)
+  --strip-height PX   piano-roll strip height per club in pixels (default 8, png only)
+`)
+}
+
+type intList []int
+
+func (l *intList) String() string {
+	if l == nil {
+		return ""
+	}
+	strs := make([]string, len(*l))
+	for i, n := range *l {
+		strs[i] = strconv.Itoa(n)
+	}
+	return strings.Join(strs, ",")
+}
+
+func (l *intList) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("--club: %q is not a number", s)
+	}
+	*l = append(*l, n)
+	return nil
+}
+
+type commonFlags struct {
+	program  string
+	labels   string
+	clubs    intList
+	allClubs bool
+	out      string
+}
+
+func loadProgram(path string) (glow.Program, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return glow.ParseProgram(f)
+}
+
+func loadLabels(path string) (map[string]glow.Label, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return glow.ReadLabelsFile(path)
+}
+
+// resolveForClub runs the specialize/color/labels/time pipeline for a
+// single club (or the whole program, if club is nil).
+func resolveForClub(program glow.Program, labels map[string]glow.Label, club *int) (glow.Program, error) {
+	p := program
+	var err error
+	if club != nil {
+		p, err = p.SpecializeForClub(*club)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p, err = p.ResolveColor()
+	if err != nil {
+		return nil, err
+	}
+	p, err = p.ResolveLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	p, err = p.ResolveTime()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func runCompile(args []string, format string) error {
+	fs := flag.NewFlagSet("compile", flag.ContinueOnError)
+	var formatFlag string
+	fs.StringVar(&formatFlag, "format", format, "output format: raw, annotated, or json")
+	cf, err := parseCommonFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	format = formatFlag
+
+	program, err := loadProgram(cf.program)
+	if err != nil {
+		return fmt.Errorf("reading program: %w", err)
+	}
+	labels, err := loadLabels(cf.labels)
+	if err != nil {
+		return fmt.Errorf("reading labels: %w", err)
+	}
+
+	clubs := cf.clubs
+	if cf.allClubs {
+		discovered, err := glow.DiscoverClubs(program)
+		if err != nil {
+			return err
+		}
+		clubs = discovered
+	}
+
+	if len(clubs) == 0 {
+		resolved, err := resolveForClub(program, labels, nil)
+		if err != nil {
+			return err
+		}
+		return writeOutput(resolved, cf.out, format)
+	}
+
+	if len(clubs) > 1 && cf.out != "" && !strings.Contains(cf.out, "%d") {
+		return fmt.Errorf("--out must contain \"%%d\" when emitting multiple clubs")
+	}
+	for _, club := range clubs {
+		club := club
+		resolved, err := resolveForClub(program, labels, &club)
+		if err != nil {
+			return fmt.Errorf("club %d: %w", club, err)
+		}
+		out := cf.out
+		if out != "" {
+			out = fmt.Sprintf(out, club)
+		}
+		if err := writeOutput(resolved, out, format); err != nil {
+			return fmt.Errorf("club %d: %w", club, err)
+		}
+	}
+	return nil
+}
+
+func parseCommonFlags(fs *flag.FlagSet, args []string) (*commonFlags, error) {
+	cf := &commonFlags{}
+	fs.StringVar(&cf.program, "program", "", "program source file (required)")
+	fs.StringVar(&cf.labels, "labels", "", "Audacity label file (.aup, .aup3, or .txt)")
+	fs.Var(&cf.clubs, "club", "club number to specialize for (repeatable)")
+	fs.BoolVar(&cf.allClubs, "all-clubs", false, "emit one output per club referenced by the program")
+	fs.StringVar(&cf.out, "out", "", "output path (stdout if omitted, or a \"%d\" pattern for multiple clubs)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if cf.program == "" {
+		return nil, fmt.Errorf("--program is required")
+	}
+	return cf, nil
+}
+
+func writeOutput(p glow.Program, out, format string) error {
+	w := os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return writeFormat(p, f, format)
+	}
+	return writeFormat(p, w, format)
+}
+
+func writeFormat(p glow.Program, w *os.File, format string) error {
+	switch format {
+	case "raw", "":
+		return p.WriteRaw(w)
+	case "annotated":
+		return p.WriteAnnotated(w)
+	case "json":
+		return p.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown --format %q", format)
+	}
+}
+
+// resolvedClubPrograms runs the specialize/color/labels/time pipeline
+// for each of clubs, or for club number 1 alone if clubs is empty.
+func resolvedClubPrograms(program glow.Program, labels map[string]glow.Label, clubs []int) (map[int]glow.Program, error) {
+	if len(clubs) == 0 {
+		clubs = []int{1}
+	}
+	programs := make(map[int]glow.Program, len(clubs))
+	for _, club := range clubs {
+		club := club
+		resolved, err := resolveForClub(program, labels, &club)
+		if err != nil {
+			return nil, fmt.Errorf("club %d: %w", club, err)
+		}
+		programs[club] = resolved
+	}
+	return programs, nil
+}
+
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	var rate int
+	var format string
+	var stripHeight int
+	fs.IntVar(&rate, "rate", glow.DefaultTickRate, "sampling rate in Hz")
+	fs.StringVar(&format, "format", "json", "output format: json, csv, or png")
+	fs.IntVar(&stripHeight, "strip-height", 8, "piano-roll strip height per club in pixels (png only)")
+	cf, err := parseCommonFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	program, err := loadProgram(cf.program)
+	if err != nil {
+		return fmt.Errorf("reading program: %w", err)
+	}
+	labels, err := loadLabels(cf.labels)
+	if err != nil {
+		return fmt.Errorf("reading labels: %w", err)
+	}
+
+	clubs := cf.clubs
+	if cf.allClubs {
+		discovered, err := glow.DiscoverClubs(program)
+		if err != nil {
+			return err
+		}
+		clubs = discovered
+	}
+
+	programs, err := resolvedClubPrograms(program, labels, clubs)
+	if err != nil {
+		return err
+	}
+	frames, err := glow.Simulate(programs, rate)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if cf.out != "" {
+		f, err := os.Create(cf.out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch format {
+	case "json", "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(frames)
+	case "csv":
+		return glow.WriteCSV(frames, w)
+	case "png":
+		return glow.WritePianoRoll(frames, stripHeight, w)
+	default:
+		return fmt.Errorf("unknown --format %q", format)
+	}
+}
+
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	cf, err := parseCommonFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(cf.program)
+	if err != nil {
+		return fmt.Errorf("reading program: %w", err)
+	}
+	defer f.Close()
+	labels, err := loadLabels(cf.labels)
+	if err != nil {
+		return fmt.Errorf("reading labels: %w", err)
+	}
+
+	problems := glow.Check(f, labels)
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	for _, p := range problems {
+		fmt.Printf("%s:%d: %s: %s\n", cf.program, p.Line, p.Severity, p.Message)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
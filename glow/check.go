@@ -0,0 +1,292 @@
+package glow
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Severity classifies a Problem found by Check.
+type Severity string
+
+const (
+	// SeverityError marks a problem that would make resolution or
+	// hardware playback fail outright.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a problem that is syntactically valid but
+	// almost certainly not what the author intended.
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is one issue found while checking a program.
+type Problem struct {
+	Line     int
+	Severity Severity
+	Message  string
+}
+
+// Check validates a program read from r against labels (which may be
+// nil if no label file was given) and reports every problem it finds,
+// rather than stopping at the first one. Unlike ParseProgram, Check
+// never fails to produce a result: malformed input is itself reported
+// as a Problem. A command with fewer fields than its arguments need is
+// skipped without a report.
+func Check(r io.Reader, labels map[string]Label) []Problem {
+	scanner := bufio.NewScanner(r)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	ck := &checker{labels: labels}
+	commands, lineNo := ck.parseLines(lines, 0)
+	for lineNo < len(lines) {
+		ck.report(lineNo, SeverityError, "E without matching L/CLUBS")
+		more, newLineNo := ck.parseLines(lines, lineNo+1)
+		commands = append(commands, more...)
+		lineNo = newLineNo
+	}
+	ck.checkCommands(commands, true, make(map[string]Color), nil, 0, false)
+	return ck.problems
+}
+
+type checker struct {
+	problems []Problem
+	labels   map[string]Label
+}
+
+func (ck *checker) report(line int, severity Severity, format string, args ...interface{}) {
+	ck.problems = append(ck.problems, Problem{Line: line, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// parseLines is a lenient twin of the package-level parseLines: instead
+// of failing outright on an unterminated block, it records the problem
+// and keeps scanning so later problems are still found.
+func (ck *checker) parseLines(lines []string, startLineNo int) (commands []Command, lineNo int) {
+	lineNo = startLineNo
+	for lineNo < len(lines) {
+		fields := splitLine(lines[lineNo])
+		if fields[0] == "E" {
+			break
+		}
+		c, newLineNo := ck.parseCommand(lines, lineNo, fields)
+		commands = append(commands, c)
+		lineNo = newLineNo
+	}
+	return commands, lineNo
+}
+
+func (ck *checker) parseCommand(lines []string, startLineNo int, fields []string) (c Command, lineNo int) {
+	lineNo = startLineNo
+	c = Command{Line: lines[lineNo], LineNo: lineNo, Fields: fields}
+	if isBlockCommand(fields[0]) {
+		subCommands, newLineNo := ck.parseLines(lines, lineNo+1)
+		c.SubCommands = subCommands
+		if newLineNo >= len(lines) {
+			ck.report(lineNo, SeverityError, "unterminated %s block", fields[0])
+			return c, newLineNo
+		}
+		c.EndLine = lines[newLineNo]
+		lineNo = newLineNo
+	}
+	lineNo++
+	return c, lineNo
+}
+
+// checkCommands walks a (lenient) parse tree in document order, tracking
+// the state needed for the stateful checks: known colors, the last
+// color set so a no-op RAMP can be spotted, and cumulative time so a
+// backward TIME can be spotted.
+//
+// time is the real elapsed time carried in from the enclosing scope:
+// a CLUBS block is inlined in place by SpecializeForClub, so TIME
+// inside one must be checked against the parent's cumulative time, not
+// against zero. inLoop is true once we're inside an L block, where
+// TIME is always fatal at resolve time (Duration has no notion of
+// "the current time" partway through a repeated loop body) - it stays
+// true for anything nested inside that L, CLUBS included. It returns
+// the cumulative time and last color after cs, for the caller to carry
+// into whatever follows.
+func (ck *checker) checkCommands(cs []Command, allowDefineColor bool, colors map[string]Color, prevColor *Color, time int, inLoop bool) (int, *Color) {
+	for _, c := range cs {
+		if len(c.Fields) == 0 || c.Fields[0] == "" {
+			continue
+		}
+		advance := 0
+		switch c.Fields[0] {
+		case "COLOR":
+			if !allowDefineColor {
+				ck.report(c.LineNo, SeverityError, "colors can only be defined at the top level")
+				continue
+			}
+			if len(c.Fields) < 3 {
+				continue
+			}
+			name := c.Fields[1]
+			if _, ok := colors[name]; ok {
+				ck.report(c.LineNo, SeverityError, "color %s redefined", name)
+			}
+			if col, ok := ck.resolveColorLiteral(colors, c.Fields[2:], c.LineNo); ok {
+				colors[name] = col
+			}
+		case "C":
+			if len(c.Fields) < 2 {
+				continue
+			}
+			if col, ok := ck.resolveColorRef(colors, c.Fields[1:], c.LineNo); ok {
+				prevColor = &col
+			}
+		case "RAMP":
+			if len(c.Fields) < 3 {
+				continue
+			}
+			if col, ok := ck.resolveColorRef(colors, c.Fields[1:len(c.Fields)-1], c.LineNo); ok {
+				if prevColor != nil && *prevColor == col {
+					ck.report(c.LineNo, SeverityWarning, "RAMP target color is the same as the current color (no-op)")
+				}
+				prevColor = &col
+			}
+			if n, ok := ck.atoi(c.Fields[len(c.Fields)-1], c.LineNo); ok {
+				if n == 0 {
+					ck.report(c.LineNo, SeverityError, "RAMP has zero duration")
+				}
+				advance = n
+			}
+		case "D":
+			if len(c.Fields) < 2 {
+				continue
+			}
+			if n, ok := ck.atoi(c.Fields[1], c.LineNo); ok {
+				if n == 0 {
+					ck.report(c.LineNo, SeverityError, "D has zero duration")
+				}
+				advance = n
+			}
+		case "TIME":
+			if len(c.Fields) < 2 {
+				continue
+			}
+			if inLoop {
+				ck.report(c.LineNo, SeverityError, "TIME is not supported inside an L block")
+				continue
+			}
+			target, ok := ck.resolveTimeTarget(c.Fields[1], c.LineNo)
+			if ok {
+				if target < time {
+					ck.report(c.LineNo, SeverityError, "TIME target %d goes backward - time is already %d", target, time)
+				} else {
+					time = target
+				}
+			}
+		case "CLUBS":
+			for _, f := range c.Fields[1:] {
+				n, err := strconv.Atoi(f)
+				if err != nil {
+					ck.report(c.LineNo, SeverityError, "CLUBS: %q is not a club number", f)
+					continue
+				}
+				if n <= 0 {
+					ck.report(c.LineNo, SeverityError, "CLUBS references nonexistent club number %d", n)
+				}
+			}
+			// A CLUBS block is inlined in place by SpecializeForClub, so
+			// its body shares the enclosing scope's cumulative time.
+			time, prevColor = ck.checkCommands(c.SubCommands, false, colors, prevColor, time, inLoop)
+			continue
+		case "L":
+			count := 0
+			if len(c.Fields) >= 2 {
+				count, _ = ck.atoi(c.Fields[1], c.LineNo)
+			}
+			// An L block repeats its body count times; TIME can't
+			// target a point partway through a repeated loop, so its
+			// body is checked with inLoop forced on and its own,
+			// independent elapsed-time count starting from zero.
+			bodyTime, bodyColor := ck.checkCommands(c.SubCommands, false, colors, prevColor, 0, true)
+			if count > 0 {
+				prevColor = bodyColor
+			}
+			advance = bodyTime * count
+		}
+		time += advance
+	}
+	return time, prevColor
+}
+
+// resolveColorLiteral resolves a COLOR definition's RGB fields, which
+// are either three literal numbers or a reference to an
+// already-defined color plus an optional brightness percentage.
+func (ck *checker) resolveColorLiteral(colors map[string]Color, fields []string, lineNo int) (Color, bool) {
+	if len(fields) == 3 {
+		r, rOk := ck.atoi(fields[0], lineNo)
+		g, gOk := ck.atoi(fields[1], lineNo)
+		b, bOk := ck.atoi(fields[2], lineNo)
+		if !rOk || !gOk || !bOk {
+			return Color{}, false
+		}
+		return Color{R: r, G: g, B: b}, true
+	}
+	return ck.resolveColorRef(colors, fields, lineNo)
+}
+
+// resolveColorRef resolves a "color name [NN%]" reference against
+// already-known colors, reporting an undefined-color problem if it
+// doesn't resolve.
+func (ck *checker) resolveColorRef(colors map[string]Color, fields []string, lineNo int) (Color, bool) {
+	if len(fields) == 3 {
+		r, rOk := ck.atoi(fields[0], lineNo)
+		g, gOk := ck.atoi(fields[1], lineNo)
+		b, bOk := ck.atoi(fields[2], lineNo)
+		if !rOk || !gOk || !bOk {
+			return Color{}, false
+		}
+		return Color{R: r, G: g, B: b}, true
+	}
+	matches := colorRegexp.FindStringSubmatch(fields[0])
+	if matches == nil {
+		ck.report(lineNo, SeverityError, "malformed color %q", fields[0])
+		return Color{}, false
+	}
+	col, ok := colors[matches[1]]
+	if !ok {
+		ck.report(lineNo, SeverityError, "color %s not defined", matches[1])
+		return Color{}, false
+	}
+	if matches[3] != "" {
+		pct, ok := ck.atoi(matches[3], lineNo)
+		if !ok {
+			return Color{}, false
+		}
+		scale := float64(pct) / 100.0
+		col.R = int(float64(col.R) * scale)
+		col.G = int(float64(col.G) * scale)
+		col.B = int(float64(col.B) * scale)
+	}
+	return col, true
+}
+
+func (ck *checker) resolveTimeTarget(field string, lineNo int) (int, bool) {
+	if isAllDigits(field) {
+		return ck.atoi(field, lineNo)
+	}
+	if ck.labels == nil {
+		ck.report(lineNo, SeverityError, "label %s not defined", field)
+		return 0, false
+	}
+	l, ok := ck.labels[field]
+	if !ok {
+		ck.report(lineNo, SeverityError, "label %s not defined", field)
+		return 0, false
+	}
+	return l.Start, true
+}
+
+func (ck *checker) atoi(field string, lineNo int) (int, bool) {
+	n, err := strconv.Atoi(field)
+	if err != nil {
+		ck.report(lineNo, SeverityError, "%q is not a number", field)
+		return 0, false
+	}
+	return n, true
+}
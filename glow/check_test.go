@@ -0,0 +1,84 @@
+package glow
+
+import (
+	"strings"
+	"testing"
+)
+
+func checkSrc(src string, labels map[string]Label) []Problem {
+	return Check(strings.NewReader(src), labels)
+}
+
+func assertOneProblem(t *testing.T, src string, severity Severity, substr string) {
+	t.Helper()
+	problems := checkSrc(src, nil)
+	if len(problems) != 1 {
+		t.Fatalf("Check(%q) = %+v, want exactly one problem", src, problems)
+	}
+	if problems[0].Severity != severity {
+		t.Errorf("Check(%q) severity = %s, want %s", src, problems[0].Severity, severity)
+	}
+	if !strings.Contains(problems[0].Message, substr) {
+		t.Errorf("Check(%q) message = %q, want it to contain %q", src, problems[0].Message, substr)
+	}
+}
+
+func TestCheckCleanProgram(t *testing.T) {
+	src := "COLOR,red,255,0,0\nCLUBS,1,2\nC,red\nD,100\nE\nL,2\nD,10\nE\n"
+	if problems := checkSrc(src, nil); len(problems) != 0 {
+		t.Errorf("Check(%q) = %+v, want no problems", src, problems)
+	}
+}
+
+func TestCheckUndefinedColor(t *testing.T) {
+	assertOneProblem(t, "C,mystery\n", SeverityError, "not defined")
+}
+
+func TestCheckUndefinedLabel(t *testing.T) {
+	assertOneProblem(t, "TIME,intro\n", SeverityError, "not defined")
+}
+
+func TestCheckBackwardTime(t *testing.T) {
+	assertOneProblem(t, "D,500\nTIME,10\n", SeverityError, "backward")
+}
+
+func TestCheckUnterminatedBlock(t *testing.T) {
+	assertOneProblem(t, "L,2\nD,10\n", SeverityError, "unterminated")
+}
+
+func TestCheckNonexistentClub(t *testing.T) {
+	assertOneProblem(t, "CLUBS,0\nD,10\nE\n", SeverityError, "nonexistent club")
+}
+
+func TestCheckZeroDuration(t *testing.T) {
+	assertOneProblem(t, "D,0\n", SeverityError, "zero duration")
+}
+
+func TestCheckRedefinedColor(t *testing.T) {
+	assertOneProblem(t, "COLOR,red,255,0,0\nCOLOR,red,0,255,0\n", SeverityError, "redefined")
+}
+
+func TestCheckNoopRamp(t *testing.T) {
+	assertOneProblem(t, "COLOR,red,255,0,0\nC,red\nRAMP,red,50\n", SeverityWarning, "no-op")
+}
+
+// Regression test: a CLUBS block is inlined in place by SpecializeForClub,
+// so a TIME inside it must be checked against the real cumulative time
+// from the enclosing scope - not reset to zero at the block boundary.
+func TestCheckBackwardTimeInsideClubs(t *testing.T) {
+	assertOneProblem(t, "D,500\nCLUBS,1\nTIME,200\nD,10\nE\n", SeverityError, "backward")
+}
+
+// Regression test: TIME inside an L block is always fatal at resolve
+// time (Duration refuses to sum a loop containing TIME), so Check must
+// flag it too instead of silently treating it as an ordinary command.
+func TestCheckTimeInsideLoop(t *testing.T) {
+	assertOneProblem(t, "L,3\nTIME,200\nD,10\nE\n", SeverityError, "not supported inside an L block")
+}
+
+func TestCheckTimeWithLabels(t *testing.T) {
+	labels := map[string]Label{"intro": {Start: 150, End: 200}}
+	if problems := checkSrc("TIME,intro\nD,10\n", labels); len(problems) != 0 {
+		t.Errorf("Check with labels = %+v, want no problems", problems)
+	}
+}
@@ -0,0 +1,117 @@
+package glow
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Color is an RGB triple.
+type Color struct {
+	R, G, B int
+}
+
+var colorRegexp = regexp.MustCompile(`^([^%]+)(\s+(\d+)%)?$`)
+
+// ResolveColor looks up description (a color name, optionally suffixed
+// with a "NN%" brightness scale) against colors and returns its RGB
+// fields as strings, ready to splice into a command.
+func ResolveColor(colors map[string]Color, description string, lineNo int) ([]string, error) {
+	matches := colorRegexp.FindStringSubmatch(description)
+	if matches == nil {
+		return nil, fmt.Errorf("line %d: malformed color %q", lineNo, description)
+	}
+	name := matches[1]
+	c, ok := colors[name]
+	if !ok {
+		return nil, fmt.Errorf("line %d: color %s not defined", lineNo, name)
+	}
+	if matches[3] != "" {
+		n, err := parseNumber(matches[3], lineNo)
+		if err != nil {
+			return nil, err
+		}
+		pct := float64(n) / 100.0
+		c.R = int(float64(c.R) * pct)
+		c.G = int(float64(c.G) * pct)
+		c.B = int(float64(c.B) * pct)
+	}
+	return []string{fmt.Sprintf("%d", c.R), fmt.Sprintf("%d", c.G), fmt.Sprintf("%d", c.B)}, nil
+}
+
+func resolveColorInCommands(cs []Command, colors map[string]Color, allowDefine bool) ([]Command, error) {
+	var newCommands []Command
+	for _, c := range cs {
+		switch c.Fields[0] {
+		case "COLOR":
+			if !allowDefine {
+				return nil, fmt.Errorf("line %d: can't define colors here", c.LineNo)
+			}
+			if _, ok := colors[c.Fields[1]]; ok {
+				return nil, fmt.Errorf("line %d: color %s redefined", c.LineNo, c.Fields[1])
+			}
+			var colorFields []string
+			if len(c.Fields) == 3 {
+				cf, err := ResolveColor(colors, c.Fields[2], c.LineNo)
+				if err != nil {
+					return nil, err
+				}
+				colorFields = cf
+			} else {
+				colorFields = c.Fields[2:5]
+			}
+			r, err := parseNumber(colorFields[0], c.LineNo)
+			if err != nil {
+				return nil, err
+			}
+			g, err := parseNumber(colorFields[1], c.LineNo)
+			if err != nil {
+				return nil, err
+			}
+			b, err := parseNumber(colorFields[2], c.LineNo)
+			if err != nil {
+				return nil, err
+			}
+			colors[c.Fields[1]] = Color{R: r, G: g, B: b}
+		case "C":
+			newC := c
+			if len(c.Fields) == 2 {
+				clr, err := ResolveColor(colors, c.Fields[1], c.LineNo)
+				if err != nil {
+					return nil, err
+				}
+				newC.Fields = []string{"C", clr[0], clr[1], clr[2]}
+				newC.Line = strings.Join(newC.Fields, ",")
+			}
+			newCommands = append(newCommands, newC)
+		case "RAMP":
+			newC := c
+			if len(c.Fields) == 3 {
+				clr, err := ResolveColor(colors, c.Fields[1], c.LineNo)
+				if err != nil {
+					return nil, err
+				}
+				newC.Fields = []string{"RAMP", clr[0], clr[1], clr[2], c.Fields[2]}
+				newC.Line = strings.Join(newC.Fields, ",")
+			}
+			newCommands = append(newCommands, newC)
+		default:
+			newC := c
+			if c.HasSubCommands() {
+				sub, err := resolveColorInCommands(c.SubCommands, colors, false)
+				if err != nil {
+					return nil, err
+				}
+				newC.SubCommands = sub
+			}
+			newCommands = append(newCommands, newC)
+		}
+	}
+	return newCommands, nil
+}
+
+// ResolveColor replaces named-color COLOR/C/RAMP references with literal
+// RGB triples, consuming the COLOR definitions in the process.
+func (p Program) ResolveColor() (Program, error) {
+	return resolveColorInCommands(p, make(map[string]Color), true)
+}
@@ -0,0 +1,114 @@
+// Package glow implements the parsing, specialization, and resolution
+// pipeline for Aerotech juggling-club programs (the "PimpMyGlow"
+// language), along with the label- and color-resolution passes needed to
+// turn a program written against named colors and Audacity labels into
+// plain Aerotech text ready for hardware.
+package glow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Command is a single parsed line of a program, plus any nested commands
+// for block forms such as L and CLUBS.
+type Command struct {
+	Line        string
+	EndLine     string
+	LineNo      int
+	Fields      []string
+	SubCommands []Command
+}
+
+// Program is a sequence of top-level commands.
+type Program []Command
+
+func parseNumber(f string, lineNo int) (int, error) {
+	n, err := strconv.Atoi(f)
+	if err != nil {
+		return 0, fmt.Errorf("line %d: %q is not a number", lineNo, f)
+	}
+	return n, nil
+}
+
+func parseCount(f string, lineNo int) (int, error) {
+	n, err := parseNumber(f, lineNo)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("line %d: count can't be zero", lineNo)
+	}
+	return n, nil
+}
+
+func isBlockCommand(c string) bool {
+	return c == "L" || c == "CLUBS"
+}
+
+// HasSubCommands reports whether c is a block command (L, CLUBS) that
+// carries nested commands.
+func (c *Command) HasSubCommands() bool {
+	return isBlockCommand(c.Fields[0])
+}
+
+// Duration returns the number of centisecond ticks c takes to execute.
+// TIME is not a fixed-duration command and returns an error; callers
+// resolve TIME into D commands first via ResolveTime.
+func (c *Command) Duration() (int, error) {
+	switch c.Fields[0] {
+	case "D":
+		return parseCount(c.Fields[1], c.LineNo)
+	case "RAMP":
+		return parseCount(c.Fields[4], c.LineNo)
+	case "L":
+		count, err := parseCount(c.Fields[1], c.LineNo)
+		if err != nil {
+			return 0, err
+		}
+		duration := 0
+		for _, sc := range c.SubCommands {
+			d, err := sc.Duration()
+			if err != nil {
+				return 0, err
+			}
+			duration += d
+		}
+		return duration * count, nil
+	case "TIME":
+		return 0, fmt.Errorf("line %d: TIME not supported here", c.LineNo)
+	default:
+		if c.HasSubCommands() {
+			return 0, fmt.Errorf("line %d: unexpected sub-commands in %s", c.LineNo, c.Fields[0])
+		}
+		return 0, nil
+	}
+}
+
+// TotalDuration returns the number of centisecond ticks the whole
+// program takes to execute.
+func (p Program) TotalDuration() (int, error) {
+	total := 0
+	for _, c := range p {
+		d, err := c.Duration()
+		if err != nil {
+			return 0, err
+		}
+		total += d
+	}
+	return total, nil
+}
+
+func splitLine(lineVerbatim string) []string {
+	line := lineVerbatim
+	if strings.Contains(line, ";") {
+		line = line[0:strings.Index(line, ";")]
+	}
+	line = strings.Trim(line, " \t")
+	fields := strings.Split(line, ",")
+	for i, f := range fields {
+		fields[i] = strings.Trim(f, " \t")
+	}
+	return fields
+}
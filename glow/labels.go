@@ -0,0 +1,189 @@
+package glow
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Label is a named time span, as exported from an audio editor's label
+// track. Start and End are in centiseconds.
+type Label struct {
+	Start int
+	End   int
+}
+
+// xmlLabel's fields must be exported to work with encoding/xml.
+type xmlLabel struct {
+	Title string  `xml:"title,attr"`
+	Start float64 `xml:"t,attr"`
+	End   float64 `xml:"t1,attr"`
+}
+
+// xmlProject's fields must be exported to work with encoding/xml.
+type xmlProject struct {
+	Labels []xmlLabel `xml:"labeltrack>label"`
+}
+
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+// ReadLabelsFile opens path and reads its label track, detecting the
+// format from its contents rather than its extension:
+//
+//   - a legacy Audacity XML project (.aup)
+//   - a modern Audacity 3 SQLite project (.aup3)
+//   - a plain Audacity label export (tab-separated "start\tend\ttitle" lines)
+func ReadLabelsFile(path string) (map[string]Label, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	header, err := br.Peek(len(sqliteMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.Equal(header, sqliteMagic):
+		return readLabelsSQLite(path)
+	case looksLikeXML(header):
+		return ReadLabels(br)
+	default:
+		return readLabelsText(br)
+	}
+}
+
+func looksLikeXML(header []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(header, " \t\r\n"), []byte("<"))
+}
+
+// ReadLabels reads a legacy Audacity XML project (.aup) and returns its
+// label track as a map from label title to time span.
+func ReadLabels(reader io.Reader) (map[string]Label, error) {
+	var project xmlProject
+	if err := xml.NewDecoder(reader).Decode(&project); err != nil {
+		return nil, err
+	}
+	return labelsFromXML(project.Labels)
+}
+
+// readLabelsSQLite reads the label track out of a modern Audacity 3
+// project (.aup3), which stores the project document - the same XML
+// schema as the legacy .aup format - as a blob in the "project" table.
+func readLabelsSQLite(path string) (map[string]Label, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var doc string
+	row := db.QueryRow(`SELECT doc FROM project ORDER BY id DESC LIMIT 1`)
+	if err := row.Scan(&doc); err != nil {
+		return nil, fmt.Errorf("reading project doc from %s: %w", path, err)
+	}
+
+	return ReadLabels(strings.NewReader(doc))
+}
+
+// readLabelsText reads a plain Audacity label export: tab-separated
+// "start\tend\ttitle" lines, times in seconds.
+func readLabelsText(r io.Reader) (map[string]Label, error) {
+	labels := make(map[string]Label)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r\n")
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected 3 tab-separated fields, got %d", lineNo, len(fields))
+		}
+		start, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		end, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		title := fields[2]
+		if _, ok := labels[title]; ok {
+			return nil, fmt.Errorf("label %s defined more than once", title)
+		}
+		labels[title] = Label{Start: int(start * 100), End: int(end * 100)}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func labelsFromXML(xmlLabels []xmlLabel) (map[string]Label, error) {
+	labels := make(map[string]Label)
+	for _, l := range xmlLabels {
+		if _, ok := labels[l.Title]; ok {
+			return nil, fmt.Errorf("label %s defined more than once", l.Title)
+		}
+		labels[l.Title] = Label{Start: int(l.Start * 100), End: int(l.End * 100)}
+	}
+	return labels, nil
+}
+
+// ResolveLabels rewrites TIME commands that reference a label name into
+// TIME commands with the label's literal start time.
+func (p Program) ResolveLabels(labels map[string]Label) (Program, error) {
+	var newCommands []Command
+	for _, c := range p {
+		switch c.Fields[0] {
+		case "TIME":
+			newC := c
+			if !isAllDigits(c.Fields[1]) {
+				l, ok := labels[c.Fields[1]]
+				if !ok {
+					return nil, fmt.Errorf("line %d: label %s not defined", c.LineNo, c.Fields[1])
+				}
+				newC.Fields = []string{"TIME", fmt.Sprintf("%d", l.Start)}
+				newC.Line = strings.Join(newC.Fields, ",")
+			}
+			newCommands = append(newCommands, newC)
+		default:
+			newC := c
+			if c.HasSubCommands() {
+				sub, err := Program(c.SubCommands).ResolveLabels(labels)
+				if err != nil {
+					return nil, err
+				}
+				newC.SubCommands = sub
+			}
+			newCommands = append(newCommands, newC)
+		}
+	}
+	return newCommands, nil
+}
+
+func isAllDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package glow
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testProjectXML = `<?xml version="1.0"?><project><labeltrack><label t="1.5" t1="2.0" title="intro"/></labeltrack></project>`
+
+func wantIntroLabel(t *testing.T, labels map[string]Label, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l, ok := labels["intro"]
+	if !ok {
+		t.Fatalf("got %v, want a label named %q", labels, "intro")
+	}
+	if l.Start != 150 || l.End != 200 {
+		t.Errorf("got %+v, want Start=150 End=200 (centiseconds)", l)
+	}
+}
+
+func TestReadLabelsXML(t *testing.T) {
+	labels, err := ReadLabels(strings.NewReader(testProjectXML))
+	wantIntroLabel(t, labels, err)
+}
+
+func TestReadLabelsFileXML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "project.aup")
+	writeFile(t, path, testProjectXML)
+
+	labels, err := ReadLabelsFile(path)
+	wantIntroLabel(t, labels, err)
+}
+
+func TestReadLabelsFileText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.txt")
+	writeFile(t, path, "1.5\t2.0\tintro\n")
+
+	labels, err := ReadLabelsFile(path)
+	wantIntroLabel(t, labels, err)
+}
+
+func TestReadLabelsFileTextDuplicateTitle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.txt")
+	writeFile(t, path, "1.5\t2.0\tintro\n3.0\t4.0\tintro\n")
+
+	if _, err := ReadLabelsFile(path); err == nil {
+		t.Fatal("expected an error for a duplicate label title, got nil")
+	}
+}
+
+func TestReadLabelsFileSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "project.aup3")
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE project (id INTEGER PRIMARY KEY, doc TEXT)`); err != nil {
+		t.Fatalf("CREATE TABLE: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO project (doc) VALUES (?)`, testProjectXML); err != nil {
+		t.Fatalf("INSERT: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("db.Close: %v", err)
+	}
+
+	labels, err := ReadLabelsFile(path)
+	wantIntroLabel(t, labels, err)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
@@ -0,0 +1,71 @@
+package glow
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+func parseLines(lines []string, startLineNo int) (commands []Command, lineNo int, err error) {
+	lineNo = startLineNo
+	for lineNo < len(lines) {
+		fields := splitLine(lines[lineNo])
+		if fields[0] == "E" {
+			break
+		}
+		command, newLineNo, err := parseCommand(lines, lineNo, fields)
+		if err != nil {
+			return nil, 0, err
+		}
+		commands = append(commands, command)
+		lineNo = newLineNo
+	}
+	return commands, lineNo, nil
+}
+
+func parseCommand(lines []string, startLineNo int, fields []string) (c Command, lineNo int, err error) {
+	lineNo = startLineNo
+	lineVerbatim := lines[lineNo]
+	c = Command{Line: lineVerbatim, LineNo: lineNo, Fields: fields}
+	if fields[0] == "E" {
+		return Command{}, 0, fmt.Errorf("line %d: cannot parse command E", lineNo)
+	}
+	if isBlockCommand(fields[0]) {
+		subCommands, newLineNo, err := parseLines(lines, lineNo+1)
+		if err != nil {
+			return Command{}, 0, err
+		}
+		if newLineNo >= len(lines) {
+			return Command{}, 0, fmt.Errorf("line %d: unterminated loop", lineNo)
+		}
+		c.SubCommands = subCommands
+		c.EndLine = lines[newLineNo]
+		lineNo = newLineNo
+	}
+	lineNo++
+
+	return c, lineNo, nil
+}
+
+// ParseProgram reads an Aerotech club program from r and returns its
+// parsed command tree.
+func ParseProgram(r io.Reader) (Program, error) {
+	scanner := bufio.NewScanner(r)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	commands, lineNo, err := parseLines(lines, 0)
+	if err != nil {
+		return nil, err
+	}
+	if lineNo < len(lines) {
+		return nil, fmt.Errorf("line %d: E without L", lineNo)
+	}
+
+	return commands, nil
+}
@@ -0,0 +1,107 @@
+package glow
+
+import (
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, src string) Program {
+	t.Helper()
+	p, err := ParseProgram(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParseProgram(%q): %v", src, err)
+	}
+	return p
+}
+
+func TestParseProgramUnterminatedBlock(t *testing.T) {
+	_, err := ParseProgram(strings.NewReader("L,2\nD,10\n"))
+	if err == nil {
+		t.Fatal("expected an error for an unterminated L block, got nil")
+	}
+}
+
+func TestParseProgramStrayE(t *testing.T) {
+	_, err := ParseProgram(strings.NewReader("D,10\nE\n"))
+	if err == nil {
+		t.Fatal("expected an error for E without L/CLUBS, got nil")
+	}
+}
+
+func TestSpecializeForClub(t *testing.T) {
+	p := mustParse(t, "CLUBS,1,2\nD,10\nE\nD,5\n")
+
+	club1, err := p.SpecializeForClub(1)
+	if err != nil {
+		t.Fatalf("SpecializeForClub(1): %v", err)
+	}
+	if len(club1) != 2 || club1[0].Fields[0] != "D" || club1[1].Fields[0] != "D" {
+		t.Fatalf("club 1: got %+v, want two D commands (one inlined from CLUBS, one trailing)", club1)
+	}
+
+	club3, err := p.SpecializeForClub(3)
+	if err != nil {
+		t.Fatalf("SpecializeForClub(3): %v", err)
+	}
+	if len(club3) != 1 || club3[0].Fields[0] != "D" {
+		t.Fatalf("club 3: got %+v, want the CLUBS block dropped entirely", club3)
+	}
+}
+
+func TestResolveColor(t *testing.T) {
+	p := mustParse(t, "COLOR,red,255,0,0\nC,red 50%\n")
+
+	resolved, err := p.ResolveColor()
+	if err != nil {
+		t.Fatalf("ResolveColor: %v", err)
+	}
+	if len(resolved) != 1 {
+		t.Fatalf("got %d commands, want 1 (the COLOR definition should be consumed)", len(resolved))
+	}
+	want := []string{"C", "127", "0", "0"}
+	if strings.Join(resolved[0].Fields, ",") != strings.Join(want, ",") {
+		t.Errorf("C,red 50%%: got fields %v, want %v", resolved[0].Fields, want)
+	}
+}
+
+func TestResolveColorUndefined(t *testing.T) {
+	p := mustParse(t, "C,mystery\n")
+	if _, err := p.ResolveColor(); err == nil {
+		t.Fatal("expected an error for an undefined color, got nil")
+	}
+}
+
+func TestResolveTimeBackward(t *testing.T) {
+	p := mustParse(t, "D,100\nTIME,10\n")
+	if _, err := p.ResolveTime(); err == nil {
+		t.Fatal("expected an error for a TIME that goes backward, got nil")
+	}
+}
+
+func TestResolveTimeForward(t *testing.T) {
+	p := mustParse(t, "D,100\nTIME,150\n")
+	resolved, err := p.ResolveTime()
+	if err != nil {
+		t.Fatalf("ResolveTime: %v", err)
+	}
+	if len(resolved) != 2 || resolved[1].Fields[0] != "D" || resolved[1].Fields[1] != "50" {
+		t.Fatalf("got %+v, want a trailing D,50 filling the gap to TIME,150", resolved)
+	}
+}
+
+func TestDiscoverClubs(t *testing.T) {
+	p := mustParse(t, "CLUBS,2,1\nD,10\nE\nCLUBS,3\nD,5\nE\n")
+	clubs, err := DiscoverClubs(p)
+	if err != nil {
+		t.Fatalf("DiscoverClubs: %v", err)
+	}
+	want := []int{1, 2, 3}
+	if len(clubs) != len(want) {
+		t.Fatalf("got %v, want %v", clubs, want)
+	}
+	for i, c := range want {
+		if clubs[i] != c {
+			t.Fatalf("got %v, want %v", clubs, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package glow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func (c *Command) writeTo(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, c.Line); err != nil {
+		return err
+	}
+	if c.HasSubCommands() {
+		for _, sc := range c.SubCommands {
+			if err := sc.writeTo(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w, c.EndLine); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteRaw writes p back out as plain Aerotech text, unchanged aside
+// from whatever resolution passes have already run.
+func (p Program) WriteRaw(w io.Writer) error {
+	for _, c := range p {
+		if err := c.writeTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteAnnotated writes p as Aerotech text, followed by a "; time N"
+// comment after every command that advances the clock.
+func (p Program) WriteAnnotated(w io.Writer) error {
+	time := 0
+	for _, c := range p {
+		if err := c.writeTo(w); err != nil {
+			return err
+		}
+		d, err := c.Duration()
+		if err != nil {
+			return err
+		}
+		if d > 0 {
+			time += d
+			if _, err := fmt.Fprintf(w, "    ; time %d\n", time); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TimelineEntry is one line of a resolved program paired with the
+// cumulative time at which it takes effect, for JSON output.
+type TimelineEntry struct {
+	Line string `json:"line"`
+	Time int    `json:"time"`
+}
+
+// Timeline flattens p (which must already be fully resolved, i.e. no
+// CLUBS/COLOR/TIME commands remain) into a list of timeline entries. L
+// blocks are not unrolled by resolution, so Timeline repeats an L
+// block's entries count times itself, with each repetition's times
+// offset by the body's duration.
+func (p Program) Timeline() ([]TimelineEntry, error) {
+	entries, _, err := appendTimeline(nil, p, 0)
+	return entries, err
+}
+
+func appendTimeline(entries []TimelineEntry, cs []Command, time int) ([]TimelineEntry, int, error) {
+	for _, c := range cs {
+		entries = append(entries, TimelineEntry{Line: c.Line, Time: time})
+		if c.Fields[0] == "L" {
+			count, err := parseCount(c.Fields[1], c.LineNo)
+			if err != nil {
+				return nil, 0, err
+			}
+			for i := 0; i < count; i++ {
+				entries, time, err = appendTimeline(entries, c.SubCommands, time)
+				if err != nil {
+					return nil, 0, err
+				}
+			}
+			continue
+		}
+		d, err := c.Duration()
+		if err != nil {
+			return nil, 0, err
+		}
+		time += d
+	}
+	return entries, time, nil
+}
+
+// WriteJSON writes p's timeline as a JSON array.
+func (p Program) WriteJSON(w io.Writer) error {
+	entries, err := p.Timeline()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
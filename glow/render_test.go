@@ -0,0 +1,93 @@
+package glow
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTimelineLoop(t *testing.T) {
+	// C,255,0,0; D,10; L,2 { C,0,0,255; D,5 } E; C,0,255,0; D,3
+	p := Program{
+		cmd("C", "255", "0", "0"),
+		cmd("D", "10"),
+		{
+			Line:   "L,2",
+			Fields: []string{"L", "2"},
+			SubCommands: []Command{
+				cmd("C", "0", "0", "255"),
+				cmd("D", "5"),
+			},
+		},
+		cmd("C", "0", "255", "0"),
+		cmd("D", "3"),
+	}
+
+	entries, err := p.Timeline()
+	if err != nil {
+		t.Fatalf("Timeline: %v", err)
+	}
+
+	want := []TimelineEntry{
+		{Line: "C,255,0,0", Time: 0},
+		{Line: "D,10", Time: 0},
+		{Line: "L,2", Time: 10},
+		{Line: "C,0,0,255", Time: 10},
+		{Line: "D,5", Time: 10},
+		{Line: "C,0,0,255", Time: 15},
+		{Line: "D,5", Time: 15},
+		{Line: "C,0,255,0", Time: 20},
+		{Line: "D,3", Time: 20},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Timeline = %+v, want %+v", entries, want)
+	}
+	for i, e := range entries {
+		if e != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestTimelineNoLoop(t *testing.T) {
+	p := Program{
+		cmd("C", "255", "0", "0"),
+		cmd("D", "10"),
+	}
+	entries, err := p.Timeline()
+	if err != nil {
+		t.Fatalf("Timeline: %v", err)
+	}
+	want := []TimelineEntry{
+		{Line: "C,255,0,0", Time: 0},
+		{Line: "D,10", Time: 0},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Timeline = %+v, want %+v", entries, want)
+	}
+	for i, e := range entries {
+		if e != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestWriteJSONContainsLoopBody(t *testing.T) {
+	p := Program{
+		cmd("C", "255", "0", "0"),
+		{
+			Line:   "L,2",
+			Fields: []string{"L", "2"},
+			SubCommands: []Command{
+				cmd("C", "0", "0", "255"),
+				cmd("D", "5"),
+			},
+		},
+	}
+	var buf strings.Builder
+	if err := p.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	if !strings.Contains(buf.String(), "C,0,0,255") {
+		t.Errorf("WriteJSON output %q, want it to contain the loop body's color command", buf.String())
+	}
+}
@@ -0,0 +1,225 @@
+package glow
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+)
+
+// DefaultTickRate is the simulator's default sampling rate, chosen to
+// match the centisecond time unit the rest of the language uses.
+const DefaultTickRate = 100
+
+// Frame is the RGB state of one club at one simulated tick.
+type Frame struct {
+	T    int `json:"t"`
+	Club int `json:"club"`
+	R    int `json:"r"`
+	G    int `json:"g"`
+	B    int `json:"b"`
+}
+
+// Simulate replays the fully-resolved programs (already run through
+// SpecializeForClub/ResolveColor/ResolveLabels/ResolveTime, keyed by
+// club number) and samples the RGB state of every club at tickRate Hz,
+// from t=0 until the longest program finishes. A non-positive tickRate
+// uses DefaultTickRate.
+func Simulate(programs map[int]Program, tickRate int) ([]Frame, error) {
+	if tickRate <= 0 {
+		tickRate = DefaultTickRate
+	}
+
+	clubs := make([]int, 0, len(programs))
+	for club := range programs {
+		clubs = append(clubs, club)
+	}
+	sort.Ints(clubs)
+
+	maxDuration := 0
+	for _, club := range clubs {
+		d, err := programs[club].TotalDuration()
+		if err != nil {
+			return nil, fmt.Errorf("club %d: %w", club, err)
+		}
+		if d > maxDuration {
+			maxDuration = d
+		}
+	}
+
+	totalTicks := int(math.Ceil(float64(maxDuration) * float64(tickRate) / 100.0))
+	var frames []Frame
+	for tick := 0; tick <= totalTicks; tick++ {
+		t := float64(tick) * 100.0 / float64(tickRate)
+		for _, club := range clubs {
+			c, err := programs[club].ColorAt(t)
+			if err != nil {
+				return nil, fmt.Errorf("club %d: %w", club, err)
+			}
+			frames = append(frames, Frame{T: tick, Club: club, R: c.R, G: c.G, B: c.B})
+		}
+	}
+	return frames, nil
+}
+
+// simState threads the running color and elapsed time through a
+// ColorAt walk.
+type simState struct {
+	elapsed float64
+	current Color
+}
+
+// ColorAt returns p's RGB state at centisecond t. p must already be
+// fully resolved (SpecializeForClub/ResolveColor/ResolveLabels/
+// ResolveTime) - only C, D, RAMP, and L remain. Times past the end of
+// the program hold the last color set.
+func (p Program) ColorAt(t float64) (Color, error) {
+	st := &simState{}
+	if err := simulateWalk(p, t, st); err != nil {
+		return Color{}, err
+	}
+	return st.current, nil
+}
+
+// simulateWalk advances st through cs, stopping as soon as it can
+// compute the color at t. It reports via st.elapsed whether it reached
+// t (elapsed <= t < total duration of cs); if it runs out of commands
+// first, st.current holds the program's final color.
+func simulateWalk(cs []Command, t float64, st *simState) error {
+	for _, c := range cs {
+		switch c.Fields[0] {
+		case "C":
+			col, err := colorFromFields(c.Fields[1:4], c.LineNo)
+			if err != nil {
+				return err
+			}
+			st.current = col
+		case "D":
+			d, err := parseCount(c.Fields[1], c.LineNo)
+			if err != nil {
+				return err
+			}
+			if t < st.elapsed+float64(d) {
+				return nil
+			}
+			st.elapsed += float64(d)
+		case "RAMP":
+			target, err := colorFromFields(c.Fields[1:4], c.LineNo)
+			if err != nil {
+				return err
+			}
+			d, err := parseCount(c.Fields[4], c.LineNo)
+			if err != nil {
+				return err
+			}
+			if t < st.elapsed+float64(d) {
+				progress := (t - st.elapsed) / float64(d)
+				st.current = lerpColor(st.current, target, progress)
+				return nil
+			}
+			st.elapsed += float64(d)
+			st.current = target
+		case "L":
+			count, err := parseCount(c.Fields[1], c.LineNo)
+			if err != nil {
+				return err
+			}
+			subDuration, err := Program(c.SubCommands).TotalDuration()
+			if err != nil {
+				return err
+			}
+			for i := 0; i < count; i++ {
+				iterEnd := st.elapsed + float64(subDuration)
+				if t < iterEnd {
+					return simulateWalk(c.SubCommands, t, st)
+				}
+				// t is past this iteration: replay it in full (target
+				// iterEnd always lies beyond every sub-command) so
+				// st.elapsed/st.current land on its final state, then
+				// move on to the next iteration.
+				if err := simulateWalk(c.SubCommands, iterEnd, st); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func colorFromFields(fields []string, lineNo int) (Color, error) {
+	r, err := parseNumber(fields[0], lineNo)
+	if err != nil {
+		return Color{}, err
+	}
+	g, err := parseNumber(fields[1], lineNo)
+	if err != nil {
+		return Color{}, err
+	}
+	b, err := parseNumber(fields[2], lineNo)
+	if err != nil {
+		return Color{}, err
+	}
+	return Color{R: r, G: g, B: b}, nil
+}
+
+func lerpColor(from, to Color, progress float64) Color {
+	lerp := func(a, b int) int {
+		return a + int(float64(b-a)*progress)
+	}
+	return Color{R: lerp(from.R, to.R), G: lerp(from.G, to.G), B: lerp(from.B, to.B)}
+}
+
+// WriteCSV writes frames as a "t,club,r,g,b" CSV table.
+func WriteCSV(frames []Frame, w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "t,club,r,g,b"); err != nil {
+		return err
+	}
+	for _, f := range frames {
+		if _, err := fmt.Fprintf(w, "%d,%d,%d,%d,%d\n", f.T, f.Club, f.R, f.G, f.B); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePianoRoll renders frames as a PNG with time along the X axis and
+// one horizontal strip of stripHeight pixels per club along the Y axis,
+// each pixel colored by that club's RGB state at that tick.
+func WritePianoRoll(frames []Frame, stripHeight int, w io.Writer) error {
+	if stripHeight <= 0 {
+		stripHeight = 8
+	}
+
+	clubs := make([]int, 0)
+	clubRow := make(map[int]int)
+	maxTick := 0
+	for _, f := range frames {
+		if _, ok := clubRow[f.Club]; !ok {
+			clubRow[f.Club] = len(clubs)
+			clubs = append(clubs, f.Club)
+		}
+		if f.T > maxTick {
+			maxTick = f.T
+		}
+	}
+	sort.Slice(clubs, func(i, j int) bool { return clubs[i] < clubs[j] })
+	for i, club := range clubs {
+		clubRow[club] = i
+	}
+
+	width := maxTick + 1
+	height := len(clubs) * stripHeight
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for _, f := range frames {
+		row := clubRow[f.Club]
+		col := color.RGBA{R: uint8(f.R), G: uint8(f.G), B: uint8(f.B), A: 255}
+		for dy := 0; dy < stripHeight; dy++ {
+			img.Set(f.T, row*stripHeight+dy, col)
+		}
+	}
+
+	return png.Encode(w, img)
+}
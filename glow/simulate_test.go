@@ -0,0 +1,157 @@
+package glow
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func cmd(fields ...string) Command {
+	return Command{Fields: fields, Line: strings.Join(fields, ",")}
+}
+
+func TestColorAtRampInterpolates(t *testing.T) {
+	p := Program{
+		cmd("C", "0", "0", "0"),
+		cmd("RAMP", "100", "200", "300", "100"),
+	}
+
+	cases := []struct {
+		t    float64
+		want Color
+	}{
+		{0, Color{0, 0, 0}},
+		{50, Color{50, 100, 150}},
+		{99, Color{99, 198, 297}},
+	}
+	for _, c := range cases {
+		got, err := p.ColorAt(c.t)
+		if err != nil {
+			t.Fatalf("ColorAt(%v): %v", c.t, err)
+		}
+		if got != c.want {
+			t.Errorf("ColorAt(%v) = %+v, want %+v", c.t, got, c.want)
+		}
+	}
+}
+
+func TestColorAtHoldsPastEnd(t *testing.T) {
+	p := Program{
+		cmd("C", "255", "0", "0"),
+		cmd("D", "10"),
+	}
+	got, err := p.ColorAt(1000)
+	if err != nil {
+		t.Fatalf("ColorAt: %v", err)
+	}
+	if want := (Color{255, 0, 0}); got != want {
+		t.Errorf("ColorAt(1000) = %+v, want %+v (the last color held)", got, want)
+	}
+}
+
+func TestColorAtLoopRepeatsBody(t *testing.T) {
+	// C,red; D,10; L,2 { C,blue; D,10 }
+	p := Program{
+		cmd("C", "255", "0", "0"),
+		cmd("D", "10"),
+		{
+			Fields: []string{"L", "2"},
+			SubCommands: []Command{
+				cmd("C", "0", "0", "255"),
+				cmd("D", "10"),
+			},
+		},
+	}
+
+	cases := []struct {
+		t    float64
+		want Color
+	}{
+		{5, Color{255, 0, 0}},  // before the loop
+		{15, Color{0, 0, 255}}, // first iteration
+		{25, Color{0, 0, 255}}, // second iteration
+	}
+	for _, c := range cases {
+		got, err := p.ColorAt(c.t)
+		if err != nil {
+			t.Fatalf("ColorAt(%v): %v", c.t, err)
+		}
+		if got != c.want {
+			t.Errorf("ColorAt(%v) = %+v, want %+v", c.t, got, c.want)
+		}
+	}
+}
+
+func TestSimulateGoldenTimeline(t *testing.T) {
+	programs := map[int]Program{
+		1: {
+			cmd("C", "255", "0", "0"),
+			cmd("D", "20"),
+		},
+	}
+
+	frames, err := Simulate(programs, 10)
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+
+	want := []Frame{
+		{T: 0, Club: 1, R: 255, G: 0, B: 0},
+		{T: 1, Club: 1, R: 255, G: 0, B: 0},
+		{T: 2, Club: 1, R: 255, G: 0, B: 0},
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("Simulate = %+v, want %+v", frames, want)
+	}
+	for i, f := range frames {
+		if f != want[i] {
+			t.Errorf("frame %d = %+v, want %+v", i, f, want[i])
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	frames := []Frame{
+		{T: 0, Club: 1, R: 255, G: 0, B: 0},
+		{T: 1, Club: 1, R: 0, G: 255, B: 0},
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(frames, &buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	want := "t,club,r,g,b\n0,1,255,0,0\n1,1,0,255,0\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWritePianoRoll(t *testing.T) {
+	frames := []Frame{
+		{T: 0, Club: 1, R: 255, G: 0, B: 0},
+		{T: 0, Club: 2, R: 0, G: 0, B: 255},
+		{T: 1, Club: 1, R: 255, G: 0, B: 0},
+		{T: 1, Club: 2, R: 0, G: 0, B: 255},
+	}
+	var buf bytes.Buffer
+	if err := WritePianoRoll(frames, 4, &buf); err != nil {
+		t.Fatalf("WritePianoRoll: %v", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode: %v", err)
+	}
+	b := img.Bounds()
+	if b.Dx() != 2 || b.Dy() != 8 {
+		t.Fatalf("bounds = %v, want 2x8 (2 ticks, 2 clubs * 4px strips)", b)
+	}
+	r, g, bl, _ := img.At(0, 0).RGBA()
+	if r>>8 != 255 || g>>8 != 0 || bl>>8 != 0 {
+		t.Errorf("pixel (0,0) = (%d,%d,%d), want club 1's red", r>>8, g>>8, bl>>8)
+	}
+	r, g, bl, _ = img.At(0, 4).RGBA()
+	if r>>8 != 0 || g>>8 != 0 || bl>>8 != 255 {
+		t.Errorf("pixel (0,4) = (%d,%d,%d), want club 2's blue", r>>8, g>>8, bl>>8)
+	}
+}
@@ -0,0 +1,79 @@
+package glow
+
+import "sort"
+
+// DiscoverClubs walks p and returns the sorted, deduplicated set of club
+// numbers referenced by any CLUBS block, for callers that want to emit
+// one output per club without being told the club list up front.
+func DiscoverClubs(p Program) ([]int, error) {
+	seen := make(map[int]bool)
+	if err := discoverClubs(p, seen); err != nil {
+		return nil, err
+	}
+	clubs := make([]int, 0, len(seen))
+	for c := range seen {
+		clubs = append(clubs, c)
+	}
+	sort.Ints(clubs)
+	return clubs, nil
+}
+
+func discoverClubs(p Program, seen map[int]bool) error {
+	for _, c := range p {
+		if c.Fields[0] == "CLUBS" {
+			for _, f := range c.Fields[1:] {
+				n, err := parseCount(f, c.LineNo)
+				if err != nil {
+					return err
+				}
+				seen[n] = true
+			}
+		}
+		if c.HasSubCommands() {
+			if err := discoverClubs(c.SubCommands, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SpecializeForClub strips out CLUBS blocks that don't apply to club,
+// inlining the sub-commands of any block that does.
+func (p Program) SpecializeForClub(club int) (Program, error) {
+	var newCommands []Command
+	for _, c := range p {
+		switch c.Fields[0] {
+		case "CLUBS":
+			found := false
+			for _, f := range c.Fields[1:] {
+				n, err := parseCount(f, c.LineNo)
+				if err != nil {
+					return nil, err
+				}
+				if n == club {
+					found = true
+					break
+				}
+			}
+			if found {
+				subCommands, err := Program(c.SubCommands).SpecializeForClub(club)
+				if err != nil {
+					return nil, err
+				}
+				newCommands = append(newCommands, subCommands...)
+			}
+		default:
+			newC := c
+			if c.HasSubCommands() {
+				subCommands, err := Program(c.SubCommands).SpecializeForClub(club)
+				if err != nil {
+					return nil, err
+				}
+				newC.SubCommands = subCommands
+			}
+			newCommands = append(newCommands, newC)
+		}
+	}
+	return newCommands, nil
+}
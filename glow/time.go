@@ -0,0 +1,41 @@
+package glow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResolveTime rewrites TIME commands into D commands holding the
+// previous state until the target time, using the cumulative duration
+// of preceding commands as the current time. TIME targets must be
+// non-decreasing.
+func (p Program) ResolveTime() (Program, error) {
+	var newCommands []Command
+	time := 0
+	for _, c := range p {
+		switch c.Fields[0] {
+		case "TIME":
+			target, err := parseCount(c.Fields[1], c.LineNo)
+			if err != nil {
+				return nil, err
+			}
+			if target < time {
+				return nil, fmt.Errorf("line %d: cannot go back in time - it's already %d", c.LineNo, time)
+			}
+			if target == time {
+				continue
+			}
+			fields := []string{"D", fmt.Sprintf("%d", target-time)}
+			newCommands = append(newCommands, Command{Line: strings.Join(fields, ","), Fields: fields, LineNo: c.LineNo})
+			time = target
+		default:
+			newCommands = append(newCommands, c)
+			d, err := c.Duration()
+			if err != nil {
+				return nil, err
+			}
+			time += d
+		}
+	}
+	return newCommands, nil
+}